@@ -1,11 +1,15 @@
 package main
 
 import (
+	"bolthelper/internal/browse"
 	"bolthelper/internal/common"
 	"bolthelper/internal/export"
 	"bolthelper/internal/get"
+	"bolthelper/internal/importer"
 	"bolthelper/internal/listkeys"
 	"bolthelper/internal/search"
+	"bolthelper/internal/serve"
+	"bolthelper/internal/write"
 	"flag"
 	"fmt"
 	"os"
@@ -34,10 +38,15 @@ func main() {
 		res := common.CmdMeta(db)
 		common.PrintJSON(res)
 	case "lsb":
-		var dbPath, bucketPath string
+		var dbPath, bucketPath, profileSpec string
 		flag.StringVar(&dbPath, "db", "", "DB path")
 		flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
+		flag.StringVar(&profileSpec, "profile", "", "key profile: inline pathGlob=type rules, or a JSON/YAML file")
 		flag.Parse()
+		profile, err := common.LoadKeyProfile(profileSpec)
+		if err != nil {
+			common.Fail("load profile", err)
+		}
 		db, err := common.OpenDB(dbPath)
 		if err != nil {
 			common.Fail("open db", err)
@@ -48,7 +57,7 @@ func main() {
 			if bucketPath != "" {
 				path = strings.Split(bucketPath, "/")
 			}
-			res := common.CmdListBuckets(tx, path)
+			res := common.CmdListBuckets(tx, path, profile)
 			common.PrintJSON(res)
 			return nil
 		})
@@ -58,8 +67,16 @@ func main() {
 		get.Run()
 	case "export":
 		export.Run()
+	case "import":
+		importer.Run()
 	case "search":
 		search.Run()
+	case "browse":
+		browse.Run()
+	case "serve":
+		serve.Run()
+	case "write":
+		write.Run()
 	default:
 		fmt.Fprintln(os.Stderr, "unknown subcommand")
 		os.Exit(1)
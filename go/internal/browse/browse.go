@@ -0,0 +1,608 @@
+package browse
+
+import (
+	"bolthelper/internal/common"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"go.etcd.io/bbolt"
+)
+
+// node is a single entry in the bucket tree. Children are loaded lazily
+// the first time a bucket is expanded, so opening a large DB stays cheap.
+type node struct {
+	name     string
+	path     []string
+	depth    int
+	children []*node
+	expanded bool
+	loaded   bool
+}
+
+type kvRow struct {
+	key      []byte
+	value    []byte
+	isBucket bool
+}
+
+type pane int
+
+const (
+	paneTree pane = iota
+	paneKV
+)
+
+type model struct {
+	screen tcell.Screen
+	dbPath string
+	db     *bbolt.DB // read-only; swapped for a RW handle for the duration of an edit
+
+	roots []*node
+	flat  []*node // flattened, visible tree rows, rebuilt on expand/collapse
+
+	cursor     int // index into flat
+	kv         []kvRow
+	kvCursor   int
+	active     pane
+	filter     string
+	filtering  bool
+	status     string
+	viewingVal bool
+	quit       bool
+}
+
+// Run launches the interactive browser. It opens a read-only handle for
+// navigation and only promotes to a read-write handle for the duration of
+// a single edit, so browsing never holds the exclusive file lock.
+func Run() {
+	var dbPath string
+	flag.StringVar(&dbPath, "db", "", "DB path")
+	flag.Parse()
+	if dbPath == "" {
+		fmt.Fprintln(os.Stderr, "missing -db")
+		os.Exit(1)
+	}
+
+	db, err := common.OpenDB(dbPath)
+	if err != nil {
+		common.Fail("open db", err)
+	}
+
+	screen, err := tcell.NewScreen()
+	if err != nil {
+		common.Fail("init screen", err)
+	}
+	if err := screen.Init(); err != nil {
+		common.Fail("init screen", err)
+	}
+	defer screen.Fini()
+
+	m := &model{screen: screen, dbPath: dbPath, db: db, active: paneTree}
+	m.loadRoots()
+	m.rebuildFlat()
+	m.loadKV()
+	m.run()
+	db.Close()
+}
+
+func (m *model) loadRoots() {
+	m.roots = nil
+	m.db.View(func(tx *bbolt.Tx) error {
+		var names [][]byte
+		_ = tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			names = append(names, append([]byte(nil), name...))
+			return nil
+		})
+		sort.Slice(names, func(i, j int) bool { return string(names[i]) < string(names[j]) })
+		for _, name := range names {
+			m.roots = append(m.roots, &node{name: string(name), path: []string{string(name)}})
+		}
+		return nil
+	})
+}
+
+// loadChildren populates n's children with its nested buckets, if not done already.
+func (m *model) loadChildren(n *node) {
+	if n.loaded {
+		return
+	}
+	n.loaded = true
+	m.db.View(func(tx *bbolt.Tx) error {
+		b := common.BucketAtPath(tx, n.path)
+		if b == nil {
+			return nil
+		}
+		var names [][]byte
+		_ = b.ForEach(func(k, v []byte) error {
+			if v == nil {
+				names = append(names, append([]byte(nil), k...))
+			}
+			return nil
+		})
+		sort.Slice(names, func(i, j int) bool { return string(names[i]) < string(names[j]) })
+		for _, name := range names {
+			child := &node{name: string(name), depth: n.depth + 1}
+			child.path = append(append([]string{}, n.path...), string(name))
+			n.children = append(n.children, child)
+		}
+		return nil
+	})
+}
+
+func (m *model) rebuildFlat() {
+	m.flat = nil
+	var walk func(nodes []*node)
+	walk = func(nodes []*node) {
+		for _, n := range nodes {
+			m.flat = append(m.flat, n)
+			if n.expanded {
+				m.loadChildren(n)
+				walk(n.children)
+			}
+		}
+	}
+	walk(m.roots)
+	if m.cursor >= len(m.flat) {
+		m.cursor = len(m.flat) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+}
+
+func (m *model) currentNode() *node {
+	if m.cursor < 0 || m.cursor >= len(m.flat) {
+		return nil
+	}
+	return m.flat[m.cursor]
+}
+
+// loadKV refreshes the key/value list for the bucket under the tree cursor.
+func (m *model) loadKV() {
+	m.kv = nil
+	m.kvCursor = 0
+	n := m.currentNode()
+	if n == nil {
+		return
+	}
+	m.db.View(func(tx *bbolt.Tx) error {
+		b := common.BucketAtPath(tx, n.path)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if m.filter != "" && !strings.HasPrefix(strings.ToLower(string(k)), strings.ToLower(m.filter)) {
+				continue
+			}
+			m.kv = append(m.kv, kvRow{
+				key:      append([]byte(nil), k...),
+				value:    append([]byte(nil), v...),
+				isBucket: v == nil,
+			})
+		}
+		return nil
+	})
+}
+
+// withRW closes the read-only handle, runs fn against a fresh RW handle
+// (mirroring write.Run), then reopens read-only and refreshes the tree.
+func (m *model) withRW(fn func(db *bbolt.DB) error) error {
+	if err := m.db.Close(); err != nil {
+		return err
+	}
+	rw, err := bbolt.Open(m.dbPath, 0644, nil)
+	if err != nil {
+		db, reopenErr := common.OpenDB(m.dbPath)
+		if reopenErr != nil {
+			common.Fail("reopen db", reopenErr)
+		}
+		m.db = db
+		return err
+	}
+	err = fn(rw)
+	rw.Close()
+
+	db, reopenErr := common.OpenDB(m.dbPath)
+	if reopenErr != nil {
+		common.Fail("reopen db", reopenErr)
+	}
+	m.db = db
+	for _, n := range m.roots {
+		n.loaded = false
+		n.children = nil
+	}
+	m.loadRoots()
+	m.rebuildFlat()
+	m.loadKV()
+	return err
+}
+
+func (m *model) run() {
+	for !m.quit {
+		m.draw()
+		ev := m.screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventResize:
+			m.screen.Sync()
+		case *tcell.EventKey:
+			if m.filtering {
+				m.handleFilterKey(ev)
+				continue
+			}
+			m.handleKey(ev)
+		}
+	}
+}
+
+func (m *model) handleFilterKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyEnter, tcell.KeyEscape:
+		m.filtering = false
+		m.loadKV()
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		if len(m.filter) > 0 {
+			m.filter = m.filter[:len(m.filter)-1]
+		}
+	case tcell.KeyRune:
+		m.filter += string(ev.Rune())
+	}
+}
+
+func (m *model) handleKey(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyCtrlC:
+		m.quit = true
+	case tcell.KeyEscape:
+		if m.viewingVal {
+			m.viewingVal = false
+		} else {
+			m.quit = true
+		}
+	case tcell.KeyTab:
+		if m.active == paneTree {
+			m.active = paneKV
+		} else {
+			m.active = paneTree
+		}
+	case tcell.KeyUp:
+		m.moveCursor(-1)
+	case tcell.KeyDown:
+		m.moveCursor(1)
+	case tcell.KeyEnter, tcell.KeyRight:
+		if m.active == paneTree {
+			if n := m.currentNode(); n != nil {
+				n.expanded = true
+				m.loadChildren(n)
+				m.rebuildFlat()
+				m.loadKV()
+			}
+		} else if row := m.currentKV(); row != nil && row.isBucket {
+			m.descendIntoBucket(row.key)
+		} else {
+			m.viewingVal = true
+		}
+	case tcell.KeyLeft:
+		if n := m.currentNode(); n != nil && n.expanded {
+			n.expanded = false
+			m.rebuildFlat()
+			m.loadKV()
+		}
+	case tcell.KeyRune:
+		m.handleRune(ev.Rune())
+	}
+}
+
+func (m *model) handleRune(r rune) {
+	switch r {
+	case 'q':
+		m.quit = true
+	case 'j':
+		m.moveCursor(1)
+	case 'k':
+		m.moveCursor(-1)
+	case '/':
+		m.filtering = true
+	case 'n': // create bucket under the current node
+		m.promptCreateBucket()
+	case 'r': // rename the current bucket
+		m.promptRenameBucket()
+	case 'p': // put a key into the current bucket
+		m.promptPutKey()
+	case 'd': // delete the selected key, or the current bucket if on the tree pane
+		m.deleteSelected()
+	case 'x': // export the current bucket
+		m.exportCurrent()
+	}
+}
+
+// exportCurrent writes the selected bucket's top-level keys to
+// <dbPath>.<bucket>.export.json, one {path, keyBase64, valueBase64} JSON
+// row per key. This is not the event stream `export` writes: nested
+// buckets are not descended into and appear as rows with an empty
+// valueBase64.
+func (m *model) exportCurrent() {
+	n := m.currentNode()
+	if n == nil {
+		m.status = "select a bucket first"
+		return
+	}
+	out := fmt.Sprintf("%s.%s.export.json", m.dbPath, n.name)
+	f, err := os.Create(out)
+	if err != nil {
+		m.setStatus("export", err)
+		return
+	}
+	defer f.Close()
+	enc := json.NewEncoder(f)
+	err = m.db.View(func(tx *bbolt.Tx) error {
+		b := common.BucketAtPath(tx, n.path)
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			row := struct {
+				Path        []string `json:"path"`
+				KeyBase64   string   `json:"keyBase64"`
+				ValueBase64 string   `json:"valueBase64"`
+			}{n.path, base64.StdEncoding.EncodeToString(k), base64.StdEncoding.EncodeToString(v)}
+			if err := enc.Encode(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	m.setStatus("export to "+out, err)
+}
+
+func (m *model) moveCursor(delta int) {
+	if m.active == paneTree {
+		m.cursor += delta
+		if m.cursor < 0 {
+			m.cursor = 0
+		}
+		if m.cursor >= len(m.flat) {
+			m.cursor = len(m.flat) - 1
+		}
+		m.loadKV()
+		return
+	}
+	m.kvCursor += delta
+	if m.kvCursor < 0 {
+		m.kvCursor = 0
+	}
+	if m.kvCursor >= len(m.kv) {
+		m.kvCursor = len(m.kv) - 1
+	}
+}
+
+func (m *model) currentKV() *kvRow {
+	if m.kvCursor < 0 || m.kvCursor >= len(m.kv) {
+		return nil
+	}
+	return &m.kv[m.kvCursor]
+}
+
+func (m *model) descendIntoBucket(name []byte) {
+	n := m.currentNode()
+	if n == nil {
+		return
+	}
+	n.expanded = true
+	m.loadChildren(n)
+	for _, c := range n.children {
+		if c.name == string(name) {
+			for i, fn := range m.flat {
+				if fn == n {
+					m.cursor = i
+					break
+				}
+			}
+		}
+	}
+	m.rebuildFlat()
+	for i, fn := range m.flat {
+		if fn.depth == n.depth+1 && fn.name == string(name) {
+			m.cursor = i
+			break
+		}
+	}
+	m.active = paneTree
+	m.loadKV()
+}
+
+func (m *model) promptCreateBucket() {
+	name := m.prompt("new bucket name: ")
+	if name == "" {
+		return
+	}
+	n := m.currentNode()
+	err := m.withRW(func(db *bbolt.DB) error {
+		return db.Update(func(tx *bbolt.Tx) error {
+			if n == nil {
+				_, err := tx.CreateBucket([]byte(name))
+				return err
+			}
+			b := common.BucketAtPath(tx, n.path)
+			if b == nil {
+				return fmt.Errorf("bucket not found")
+			}
+			_, err := b.CreateBucket([]byte(name))
+			return err
+		})
+	})
+	m.setStatus("create bucket", err)
+}
+
+// promptRenameBucket renames the bucket under the tree cursor by creating
+// a new bucket under the same parent, recursively copying the old one's
+// contents into it, then deleting the old bucket.
+func (m *model) promptRenameBucket() {
+	n := m.currentNode()
+	if n == nil {
+		m.status = "select a bucket first"
+		return
+	}
+	newName := m.prompt("rename to: ")
+	if newName == "" {
+		return
+	}
+	err := m.withRW(func(db *bbolt.DB) error {
+		return db.Update(func(tx *bbolt.Tx) error {
+			var parent *bbolt.Bucket
+			if len(n.path) > 1 {
+				parent = common.BucketAtPath(tx, n.path[:len(n.path)-1])
+				if parent == nil {
+					return fmt.Errorf("parent bucket not found")
+				}
+			}
+			var old *bbolt.Bucket
+			if parent != nil {
+				old = parent.Bucket([]byte(n.name))
+			} else {
+				old = tx.Bucket([]byte(n.name))
+			}
+			if old == nil {
+				return fmt.Errorf("bucket not found")
+			}
+			var fresh *bbolt.Bucket
+			var err error
+			if parent != nil {
+				fresh, err = parent.CreateBucket([]byte(newName))
+			} else {
+				fresh, err = tx.CreateBucket([]byte(newName))
+			}
+			if err != nil {
+				return err
+			}
+			if err := copyBucketContents(fresh, old); err != nil {
+				return err
+			}
+			if parent != nil {
+				return parent.DeleteBucket([]byte(n.name))
+			}
+			return tx.DeleteBucket([]byte(n.name))
+		})
+	})
+	m.setStatus("rename bucket", err)
+}
+
+// copyBucketContents recursively copies every key and sub-bucket of src
+// into dst, the same shape the export/import stream round-trip expects.
+func copyBucketContents(dst, src *bbolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v == nil {
+			sub := src.Bucket(k)
+			newSub, err := dst.CreateBucket(append([]byte(nil), k...))
+			if err != nil {
+				return err
+			}
+			return copyBucketContents(newSub, sub)
+		}
+		return dst.Put(append([]byte(nil), k...), append([]byte(nil), v...))
+	})
+}
+
+func (m *model) promptPutKey() {
+	n := m.currentNode()
+	if n == nil {
+		m.status = "select a bucket first"
+		return
+	}
+	key := m.prompt("key: ")
+	if key == "" {
+		return
+	}
+	value := m.prompt("value: ")
+	err := m.withRW(func(db *bbolt.DB) error {
+		return db.Update(func(tx *bbolt.Tx) error {
+			b := common.BucketAtPath(tx, n.path)
+			if b == nil {
+				return fmt.Errorf("bucket not found")
+			}
+			return b.Put([]byte(key), []byte(value))
+		})
+	})
+	m.setStatus("put key", err)
+}
+
+func (m *model) deleteSelected() {
+	if m.active == paneKV {
+		row := m.currentKV()
+		n := m.currentNode()
+		if row == nil || n == nil {
+			return
+		}
+		err := m.withRW(func(db *bbolt.DB) error {
+			return db.Update(func(tx *bbolt.Tx) error {
+				b := common.BucketAtPath(tx, n.path)
+				if b == nil {
+					return fmt.Errorf("bucket not found")
+				}
+				if row.isBucket {
+					return b.DeleteBucket(row.key)
+				}
+				return b.Delete(row.key)
+			})
+		})
+		m.setStatus("delete", err)
+		return
+	}
+	n := m.currentNode()
+	if n == nil {
+		return
+	}
+	err := m.withRW(func(db *bbolt.DB) error {
+		return db.Update(func(tx *bbolt.Tx) error {
+			if len(n.path) == 1 {
+				return tx.DeleteBucket([]byte(n.name))
+			}
+			parent := common.BucketAtPath(tx, n.path[:len(n.path)-1])
+			if parent == nil {
+				return fmt.Errorf("parent bucket not found")
+			}
+			return parent.DeleteBucket([]byte(n.name))
+		})
+	})
+	m.setStatus("delete bucket", err)
+}
+
+func (m *model) setStatus(action string, err error) {
+	if err != nil {
+		m.status = fmt.Sprintf("%s failed: %v", action, err)
+		return
+	}
+	m.status = action + " ok"
+}
+
+// prompt draws a single-line input at the bottom of the screen and blocks
+// until the user presses enter (returns the text) or escape (returns "").
+func (m *model) prompt(label string) string {
+	buf := ""
+	for {
+		m.drawPrompt(label + buf)
+		ev := m.screen.PollEvent()
+		switch ev := ev.(type) {
+		case *tcell.EventKey:
+			switch ev.Key() {
+			case tcell.KeyEnter:
+				return buf
+			case tcell.KeyEscape:
+				return ""
+			case tcell.KeyBackspace, tcell.KeyBackspace2:
+				if len(buf) > 0 {
+					buf = buf[:len(buf)-1]
+				}
+			case tcell.KeyRune:
+				buf += string(ev.Rune())
+			}
+		}
+	}
+}
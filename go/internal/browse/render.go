@@ -0,0 +1,154 @@
+package browse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	styleDefault  = tcell.StyleDefault
+	styleSelected = tcell.StyleDefault.Reverse(true)
+	styleHeader   = tcell.StyleDefault.Bold(true)
+)
+
+func (m *model) draw() {
+	m.screen.Clear()
+	w, h := m.screen.Size()
+	treeWidth := w / 3
+	if treeWidth < 20 {
+		treeWidth = 20
+	}
+
+	m.drawTree(0, 0, treeWidth, h-2)
+	m.drawDivider(treeWidth, h-2)
+	if m.viewingVal {
+		m.drawHexView(treeWidth+1, 0, w-treeWidth-1, h-2)
+	} else {
+		m.drawKV(treeWidth+1, 0, w-treeWidth-1, h-2)
+	}
+	m.drawStatusLine(h - 1)
+	m.screen.Show()
+}
+
+func (m *model) drawTree(x, y, width, height int) {
+	m.puts(x, y, width, "BUCKETS", styleHeader)
+	for i, n := range m.flat {
+		row := y + 1 + i
+		if row >= y+height {
+			break
+		}
+		marker := "  "
+		if len(n.children) > 0 || !n.loaded {
+			if n.expanded {
+				marker = "- "
+			} else {
+				marker = "+ "
+			}
+		}
+		label := strings.Repeat("  ", n.depth) + marker + n.name
+		style := styleDefault
+		if i == m.cursor && m.active == paneTree {
+			style = styleSelected
+		}
+		m.puts(x, row, width, label, style)
+	}
+}
+
+func (m *model) drawDivider(x, height int) {
+	for y := 0; y < height+1; y++ {
+		m.screen.SetContent(x, y, tcell.RuneVLine, nil, styleDefault)
+	}
+}
+
+func (m *model) drawKV(x, y, width, height int) {
+	header := "KEYS"
+	if m.filtering {
+		header = fmt.Sprintf("KEYS (filter: %s_)", m.filter)
+	} else if m.filter != "" {
+		header = fmt.Sprintf("KEYS (filter: %s)", m.filter)
+	}
+	m.puts(x, y, width, header, styleHeader)
+	for i, row := range m.kv {
+		line := y + 1 + i
+		if line >= y+height {
+			break
+		}
+		kind := "key"
+		size := fmt.Sprintf("%d bytes", len(row.value))
+		if row.isBucket {
+			kind = "bucket"
+			size = ""
+		}
+		label := fmt.Sprintf("%-32s %-8s %s", string(row.key), kind, size)
+		style := styleDefault
+		if i == m.kvCursor && m.active == paneKV {
+			style = styleSelected
+		}
+		m.puts(x, line, width, label, style)
+	}
+}
+
+// drawHexView renders the value of the selected key as hex + ASCII, 16
+// bytes per row, the way a classic binary editor gutter does.
+func (m *model) drawHexView(x, y, width, height int) {
+	row := m.currentKV()
+	m.puts(x, y, width, "VALUE (esc to go back)", styleHeader)
+	if row == nil {
+		return
+	}
+	val := row.value
+	line := 0
+	for offset := 0; offset < len(val); offset += 16 {
+		if y+1+line >= y+height {
+			m.puts(x, y+height-1, width, fmt.Sprintf("... %d more bytes", len(val)-offset), styleDefault)
+			break
+		}
+		end := offset + 16
+		if end > len(val) {
+			end = len(val)
+		}
+		chunk := val[offset:end]
+		hexPart := make([]string, len(chunk))
+		asciiPart := make([]byte, len(chunk))
+		for i, b := range chunk {
+			hexPart[i] = fmt.Sprintf("%02x", b)
+			if b >= 0x20 && b < 0x7f {
+				asciiPart[i] = b
+			} else {
+				asciiPart[i] = '.'
+			}
+		}
+		text := fmt.Sprintf("%08x  %-47s  %s", offset, strings.Join(hexPart, " "), string(asciiPart))
+		m.puts(x, y+1+line, width, text, styleDefault)
+		line++
+	}
+}
+
+func (m *model) drawPrompt(text string) {
+	w, h := m.screen.Size()
+	m.puts(0, h-1, w, text, styleDefault)
+	m.screen.Show()
+}
+
+func (m *model) drawStatusLine(y int) {
+	w, _ := m.screen.Size()
+	help := "tab:pane  enter:open  left:collapse  /:filter  n:new-bucket  r:rename-bucket  p:put-key  d:delete  x:export  q:quit"
+	text := help
+	if m.status != "" {
+		text = m.status + "  |  " + help
+	}
+	m.puts(0, y, w, text, styleDefault)
+}
+
+func (m *model) puts(x, y, maxWidth int, text string, style tcell.Style) {
+	col := x
+	for _, r := range text {
+		if col >= x+maxWidth {
+			break
+		}
+		m.screen.SetContent(col, y, r, nil, style)
+		col++
+	}
+}
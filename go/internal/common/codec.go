@@ -0,0 +1,72 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Codec decodes a stored value into a normalized byte form plus a
+// human-readable text rendering. get, export, and search all decode
+// through the same registry so adding a format means adding one codec,
+// not touching every subcommand.
+type Codec interface {
+	Name() string
+	Decode(data []byte) (decoded []byte, text string, err error)
+}
+
+var codecRegistry = map[string]Codec{}
+
+func RegisterCodec(c Codec) {
+	codecRegistry[c.Name()] = c
+}
+
+// Codecs lists every registered codec name, sorted, for -codec usage text.
+func Codecs() []string {
+	names := make([]string, 0, len(codecRegistry))
+	for name := range codecRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// autoOrder is the preference order tried when no -codec is forced: the
+// more specific/compressed formats first, falling back to plain text.
+var autoOrder = []string{"gzip", "snappy", "protobuf", "msgpack", "json", "utf8", "raw"}
+
+// DecodeValue decodes data with the named codec, or probes autoOrder and
+// reports which one matched when name is "" or "auto". It never errors
+// for auto mode, since "raw" always succeeds.
+func DecodeValue(name string, data []byte) (decoded []byte, text string, usedCodec string, err error) {
+	if name != "" && name != "auto" {
+		c, ok := codecRegistry[name]
+		if !ok {
+			return nil, "", "", fmt.Errorf("unknown codec %q (have: %v)", name, Codecs())
+		}
+		decoded, text, err = c.Decode(data)
+		return decoded, text, name, err
+	}
+	for _, candidate := range autoOrder {
+		c, ok := codecRegistry[candidate]
+		if !ok {
+			continue
+		}
+		if decoded, text, err = c.Decode(data); err == nil {
+			return decoded, text, candidate, nil
+		}
+	}
+	decoded, text, _ = codecRegistry["raw"].Decode(data)
+	return decoded, text, "raw", nil
+}
+
+func init() {
+	RegisterCodec(rawCodec{})
+	RegisterCodec(utf8Codec{})
+	RegisterCodec(hexCodec{})
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(msgpackCodec{})
+	RegisterCodec(gobCodec{})
+	RegisterCodec(snappyCodec{})
+	RegisterCodec(gzipCodec{})
+	RegisterCodec(protobufCodec{})
+}
@@ -0,0 +1,182 @@
+package common
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/golang/snappy"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type rawCodec struct{}
+
+func (rawCodec) Name() string { return "raw" }
+func (rawCodec) Decode(data []byte) ([]byte, string, error) {
+	return data, string(data), nil
+}
+
+type utf8Codec struct{}
+
+func (utf8Codec) Name() string { return "utf8" }
+func (utf8Codec) Decode(data []byte) ([]byte, string, error) {
+	if !utf8.Valid(data) {
+		return nil, "", fmt.Errorf("not valid utf8")
+	}
+	return data, string(data), nil
+}
+
+type hexCodec struct{}
+
+func (hexCodec) Name() string { return "hex" }
+func (hexCodec) Decode(data []byte) ([]byte, string, error) {
+	return data, hex.EncodeToString(data), nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string { return "json" }
+func (jsonCodec) Decode(data []byte) ([]byte, string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, "", err
+	}
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	return pretty, string(pretty), nil
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string { return "msgpack" }
+func (msgpackCodec) Decode(data []byte) ([]byte, string, error) {
+	var v any
+	if err := msgpack.Unmarshal(data, &v); err != nil {
+		return nil, "", err
+	}
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	return pretty, string(pretty), nil
+}
+
+type gobCodec struct{}
+
+func (gobCodec) Name() string { return "gob" }
+
+// Decode only handles the common case of a gob-encoded map, since gob
+// otherwise needs the original concrete type registered to decode into.
+func (gobCodec) Decode(data []byte) ([]byte, string, error) {
+	var v map[string]any
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v); err != nil {
+		return nil, "", err
+	}
+	pretty, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, "", err
+	}
+	return pretty, string(pretty), nil
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string { return "snappy" }
+func (snappyCodec) Decode(data []byte) ([]byte, string, error) {
+	out, err := snappy.Decode(nil, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, string(out), nil
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string { return "gzip" }
+func (gzipCodec) Decode(data []byte) ([]byte, string, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+	defer r.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, string(out), nil
+}
+
+type protobufCodec struct{}
+
+func (protobufCodec) Name() string { return "protobuf" }
+
+// Decode dumps unknown fields by wire type, the way `protoc --decode_raw`
+// does, since there's no compiled schema to decode into a concrete message.
+func (protobufCodec) Decode(data []byte) ([]byte, string, error) {
+	text, err := decodeProtobufFields(data, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	return []byte(text), text, nil
+}
+
+func decodeProtobufFields(data []byte, depth int) (string, error) {
+	if len(data) == 0 {
+		return "", fmt.Errorf("empty message")
+	}
+	var buf bytes.Buffer
+	indent := strings.Repeat("  ", depth)
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return "", protowire.ParseError(n)
+		}
+		data = data[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(data)
+			if n < 0 {
+				return "", protowire.ParseError(n)
+			}
+			data = data[n:]
+			fmt.Fprintf(&buf, "%s%d: %d\n", indent, num, v)
+		case protowire.Fixed32Type:
+			v, n := protowire.ConsumeFixed32(data)
+			if n < 0 {
+				return "", protowire.ParseError(n)
+			}
+			data = data[n:]
+			fmt.Fprintf(&buf, "%s%d: 0x%08x\n", indent, num, v)
+		case protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(data)
+			if n < 0 {
+				return "", protowire.ParseError(n)
+			}
+			data = data[n:]
+			fmt.Fprintf(&buf, "%s%d: 0x%016x\n", indent, num, v)
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(data)
+			if n < 0 {
+				return "", protowire.ParseError(n)
+			}
+			data = data[n:]
+			if nested, err := decodeProtobufFields(v, depth+1); err == nil {
+				fmt.Fprintf(&buf, "%s%d: {\n%s%s}\n", indent, num, nested, indent)
+			} else {
+				fmt.Fprintf(&buf, "%s%d: %q\n", indent, num, string(v))
+			}
+		default:
+			return "", fmt.Errorf("unsupported wire type %d", typ)
+		}
+	}
+	return buf.String(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 
 	"go.etcd.io/bbolt"
@@ -47,13 +48,22 @@ func CmdMeta(db *bbolt.DB) Result {
 	return Result{"ok": true, "path": db.Path(), "size": size}
 }
 
-func CmdListBuckets(tx *bbolt.Tx, path []string) Result {
-	var buckets []string
+// CmdListBuckets lists the bucket names directly under path. When profile
+// has a rule for path, "bucketsDecoded" carries the same names rendered
+// per that rule ("" where a name doesn't fit the configured type).
+func CmdListBuckets(tx *bbolt.Tx, path []string, profile *KeyProfile) Result {
+	keyType := profile.TypeFor(path)
+	var buckets, decoded []string
+	addName := func(name []byte) {
+		buckets = append(buckets, Enc(name))
+		d, _ := DecodeKey(keyType, name)
+		decoded = append(decoded, d)
+	}
 	if len(path) == 0 {
 		// Root level - list all top-level buckets
 		_ = tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
 			if b != nil {
-				buckets = append(buckets, Enc(name))
+				addName(name)
 			}
 			return nil
 		})
@@ -64,14 +74,22 @@ func CmdListBuckets(tx *bbolt.Tx, path []string) Result {
 		}
 		_ = b.ForEach(func(k, v []byte) error {
 			if v == nil {
-				buckets = append(buckets, Enc(k))
+				addName(k)
 			}
 			return nil
 		})
 	}
-	return Result{"buckets": buckets}
+	res := Result{"buckets": buckets}
+	if keyType != "" {
+		res["bucketsDecoded"] = decoded
+	}
+	return res
 }
 
 func PrintJSON(v any) {
-	json.NewEncoder(os.Stdout).Encode(v)
+	PrintJSONTo(os.Stdout, v)
+}
+
+func PrintJSONTo(w io.Writer, v any) {
+	json.NewEncoder(w).Encode(v)
 }
@@ -0,0 +1,213 @@
+package common
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeyProfile maps bucket paths to a key encoding, so binary keys (object
+// IDs, integer sequences, timestamps) can be rendered and entered as text
+// instead of base64. Rules are checked in declaration order; the first
+// whose glob matches the bucket path wins.
+type KeyProfile struct {
+	rules []keyRule
+}
+
+type keyRule struct {
+	segments []string // glob split on "/"; "*" matches exactly one bucket name
+	typ      string
+}
+
+type profileFile struct {
+	Rules []struct {
+		PathGlob string `json:"pathGlob" yaml:"pathGlob"`
+		Type     string `json:"type" yaml:"type"`
+	} `json:"rules" yaml:"rules"`
+}
+
+// LoadKeyProfile parses a -profile flag value. If it names an existing
+// file, rules are read from it as JSON (or YAML, for .yaml/.yml paths).
+// Otherwise the value itself is treated as an inline rule list, e.g.
+// "containers/*=cid32,containers/*/objects/*=oid64,stats/*=uint64be".
+func LoadKeyProfile(spec string) (*KeyProfile, error) {
+	if spec == "" {
+		return nil, nil
+	}
+	if info, err := os.Stat(spec); err == nil && !info.IsDir() {
+		return loadProfileFile(spec)
+	}
+	return parseInlineProfile(spec)
+}
+
+func parseInlineProfile(spec string) (*KeyProfile, error) {
+	var kp KeyProfile
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid profile rule %q, want pathGlob=type", pair)
+		}
+		kp.rules = append(kp.rules, keyRule{segments: strings.Split(parts[0], "/"), typ: parts[1]})
+	}
+	return &kp, nil
+}
+
+func loadProfileFile(path string) (*KeyProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pf profileFile
+	if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
+		err = yaml.Unmarshal(data, &pf)
+	} else {
+		err = json.Unmarshal(data, &pf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse profile %s: %w", path, err)
+	}
+	var kp KeyProfile
+	for _, r := range pf.Rules {
+		kp.rules = append(kp.rules, keyRule{segments: strings.Split(r.PathGlob, "/"), typ: r.Type})
+	}
+	return &kp, nil
+}
+
+// TypeFor returns the key type configured for keys directly inside the
+// bucket at path, or "" if the profile is nil or no rule matches.
+func (p *KeyProfile) TypeFor(bucketPath []string) string {
+	if p == nil {
+		return ""
+	}
+	for _, r := range p.rules {
+		if len(r.segments) != len(bucketPath)+1 {
+			continue
+		}
+		matched := true
+		for i, seg := range bucketPath {
+			if r.segments[i] != "*" && r.segments[i] != seg {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return r.typ
+		}
+	}
+	return ""
+}
+
+var (
+	reFixedID = regexp.MustCompile(`^(cid|oid)(\d+)$`)
+	reUint    = regexp.MustCompile(`^uint(8|16|32|64)(be|le)$`)
+)
+
+// DecodeKey renders key as typ, returning ok=false (not an error) when typ
+// is empty, unrecognized, or the key's width doesn't match - callers fall
+// back to showing keyBase64 in that case.
+func DecodeKey(typ string, key []byte) (rendered string, ok bool) {
+	switch typ {
+	case "":
+		return "", false
+	case "hex":
+		return hex.EncodeToString(key), true
+	case "uuid":
+		if len(key) != 16 {
+			return "", false
+		}
+		return fmt.Sprintf("%x-%x-%x-%x-%x", key[0:4], key[4:6], key[6:8], key[8:10], key[10:16]), true
+	case "timestamp":
+		if len(key) != 8 {
+			return "", false
+		}
+		return time.Unix(0, int64(beUint(key))).UTC().Format(time.RFC3339Nano), true
+	}
+	if m := reFixedID.FindStringSubmatch(typ); m != nil {
+		bits, _ := strconv.Atoi(m[2])
+		if len(key)*8 != bits {
+			return "", false
+		}
+		return m[1] + ":" + hex.EncodeToString(key), true
+	}
+	if m := reUint.FindStringSubmatch(typ); m != nil {
+		size := mustAtoi(m[1]) / 8
+		if len(key) != size {
+			return "", false
+		}
+		if m[2] == "be" {
+			return strconv.FormatUint(beUint(key), 10), true
+		}
+		return strconv.FormatUint(leUint(key), 10), true
+	}
+	return "", false
+}
+
+// EncodeKey is DecodeKey's inverse for the numeric/hex types, used by
+// -key-uint64/-key-hex style flags to build raw key bytes from text.
+func EncodeKey(typ, value string) ([]byte, error) {
+	switch typ {
+	case "hex":
+		return hex.DecodeString(value)
+	}
+	if m := reUint.FindStringSubmatch(typ); m != nil {
+		bits := mustAtoi(m[1])
+		n, err := strconv.ParseUint(value, 10, bits)
+		if err != nil {
+			return nil, err
+		}
+		b := make([]byte, bits/8)
+		if m[2] == "be" {
+			putBE(b, n)
+		} else {
+			putLE(b, n)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q for encoding", typ)
+}
+
+func beUint(b []byte) uint64 {
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return v
+}
+
+func leUint(b []byte) uint64 {
+	var v uint64
+	for i := len(b) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+func putBE(b []byte, v uint64) {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func putLE(b []byte, v uint64) {
+	for i := range b {
+		b[i] = byte(v)
+		v >>= 8
+	}
+}
+
+func mustAtoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -2,20 +2,33 @@ package export
 
 import (
 	"bolthelper/internal/common"
+	"compress/gzip"
 	"encoding/base64"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"go.etcd.io/bbolt"
 )
 
-type Row struct {
+// Event is one line of an export stream. A bucket's contents are bracketed
+// by bucket_start/bucket_end so the stream can represent nested buckets
+// instead of only a single bucket's top-level keys; kv carries one key's
+// value. For kv, Path is the path to the bucket holding the key; for
+// bucket_start/bucket_end, Path is the path to the parent of the bucket
+// named by Name (empty for a top-level bucket).
+type Event struct {
+	Type        string   `json:"type"` // "bucket_start" | "kv" | "bucket_end"
 	Path        []string `json:"path"`
-	KeyBase64   string   `json:"keyBase64"`
-	ValueBase64 string   `json:"valueBase64"`
+	Name        string   `json:"name,omitempty"` // bucket name, for bucket_start/bucket_end
+	KeyBase64   string   `json:"keyBase64,omitempty"`
+	KeyDecoded  string   `json:"keyDecoded,omitempty"`
+	ValueBase64 string   `json:"valueBase64,omitempty"`
+	Codec       string   `json:"codec,omitempty"`
+	DecodedText string   `json:"decodedText,omitempty"`
 }
 
 type Result struct {
@@ -23,12 +36,85 @@ type Result struct {
 	Written string `json:"written"`
 }
 
+// WriteEvents streams path (or, with an empty path, every top-level
+// bucket) as NDJSON bucket_start/kv/bucket_end events, recursing into
+// nested buckets so a whole DB can be captured in one pass. Cursor
+// iteration order is bbolt's sorted key order, so two exports of the same
+// data are byte-identical and diffable. A non-empty codec decodes each
+// value with common.DecodeValue; profile (may be nil) renders keys per
+// KeyDecoded; gzipOut wraps the stream in a gzip.Writer.
+func WriteEvents(db *bbolt.DB, path []string, prefix, codec string, profile *common.KeyProfile, gzipOut bool, w io.Writer) error {
+	out := w
+	if gzipOut {
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	enc := json.NewEncoder(out)
+
+	return db.View(func(tx *bbolt.Tx) error {
+		if len(path) == 0 {
+			return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+				if b == nil {
+					return nil
+				}
+				return writeBucket(enc, nil, name, b, prefix, codec, profile)
+			})
+		}
+		b := common.BucketAtPath(tx, path)
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		return writeBucket(enc, path[:len(path)-1], []byte(path[len(path)-1]), b, prefix, codec, profile)
+	})
+}
+
+func writeBucket(enc *json.Encoder, parent []string, name []byte, b *bbolt.Bucket, prefix, codec string, profile *common.KeyProfile) error {
+	full := append(append([]string{}, parent...), string(name))
+	if err := enc.Encode(Event{Type: "bucket_start", Path: parent, Name: string(name)}); err != nil {
+		return err
+	}
+
+	keyType := profile.TypeFor(full)
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if prefix != "" && !strings.HasPrefix(string(k), prefix) {
+			continue
+		}
+		if v == nil {
+			if err := writeBucket(enc, full, k, b.Bucket(k), prefix, codec, profile); err != nil {
+				return err
+			}
+			continue
+		}
+		ev := Event{Type: "kv", Path: full, KeyBase64: base64.StdEncoding.EncodeToString(k), ValueBase64: base64.StdEncoding.EncodeToString(v)}
+		if decoded, ok := common.DecodeKey(keyType, k); ok {
+			ev.KeyDecoded = decoded
+		}
+		if codec != "" {
+			if _, text, used, err := common.DecodeValue(codec, v); err == nil {
+				ev.Codec = used
+				ev.DecodedText = text
+			}
+		}
+		if err := enc.Encode(ev); err != nil {
+			return err
+		}
+	}
+
+	return enc.Encode(Event{Type: "bucket_end", Path: parent, Name: string(name)})
+}
+
 func Run() {
-	var dbPath, bucketPath, out, prefix string
+	var dbPath, bucketPath, out, prefix, codec, profileSpec string
+	var gzipOut bool
 	flag.StringVar(&dbPath, "db", "", "DB path")
-	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
+	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated); empty exports the whole DB")
 	flag.StringVar(&out, "out", "", "output file")
 	flag.StringVar(&prefix, "prefix", "", "prefix filter")
+	flag.StringVar(&codec, "codec", "", "decode each value with this codec before display (auto probes, empty skips)")
+	flag.StringVar(&profileSpec, "profile", "", "key profile: inline pathGlob=type rules, or a JSON/YAML file")
+	flag.BoolVar(&gzipOut, "gzip", false, "gzip the output stream")
 	flag.Parse()
 	if dbPath == "" || out == "" {
 		fmt.Fprintln(os.Stderr, "missing required args")
@@ -38,6 +124,10 @@ func Run() {
 	if bucketPath != "" {
 		path = strings.Split(bucketPath, "/")
 	}
+	profile, err := common.LoadKeyProfile(profileSpec)
+	if err != nil {
+		common.Fail("load profile", err)
+	}
 	db, err := common.OpenDB(dbPath)
 	if err != nil {
 		common.Fail("open db", err)
@@ -48,29 +138,9 @@ func Run() {
 		common.Fail("create out", err)
 	}
 	defer f.Close()
-	enc := json.NewEncoder(f)
-	db.View(func(tx *bbolt.Tx) error {
-		var b *bbolt.Bucket
-		if len(path) > 0 {
-			b = common.BucketAtPath(tx, path)
-			if b == nil {
-				return fmt.Errorf("bucket not found")
-			}
-		} else {
-			b = tx.Cursor().Bucket()
-		}
-		c := b.Cursor()
-		for k, v := c.First(); k != nil; k, v = c.Next() {
-			if prefix != "" && !strings.HasPrefix(string(k), prefix) {
-				continue
-			}
-			row := Row{path, base64.StdEncoding.EncodeToString(k), base64.StdEncoding.EncodeToString(v)}
-			if err := enc.Encode(row); err != nil {
-				return err
-			}
-		}
-		return nil
-	})
+	if err := WriteEvents(db, path, prefix, codec, profile, gzipOut, f); err != nil {
+		common.Fail("export", err)
+	}
 	res := Result{true, out}
 	json.NewEncoder(os.Stdout).Encode(res)
 }
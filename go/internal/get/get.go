@@ -17,6 +17,8 @@ type HeadResult struct {
 	Mode            string `json:"mode"`
 	TotalSize       int    `json:"totalSize"`
 	ValueHeadBase64 string `json:"valueHeadBase64"`
+	Codec           string `json:"codec,omitempty"`
+	DecodedText     string `json:"decodedText,omitempty"`
 }
 
 type SaveResult struct {
@@ -25,55 +27,92 @@ type SaveResult struct {
 	WrittenPath string `json:"writtenPath"`
 }
 
+// resolveKey picks whichever typed key flag was set and decodes it to raw
+// bytes, erroring if more than one was given or none were.
+func resolveKey(keyBase64, keyHex, keyUint64 string) ([]byte, error) {
+	set := 0
+	for _, v := range []string{keyBase64, keyHex, keyUint64} {
+		if v != "" {
+			set++
+		}
+	}
+	switch {
+	case set == 0:
+		return nil, fmt.Errorf("one of -key, -key-hex, -key-uint64 is required")
+	case set > 1:
+		return nil, fmt.Errorf("only one of -key, -key-hex, -key-uint64 may be set")
+	case keyHex != "":
+		return common.EncodeKey("hex", keyHex)
+	case keyUint64 != "":
+		return common.EncodeKey("uint64be", keyUint64)
+	default:
+		return base64.StdEncoding.DecodeString(keyBase64)
+	}
+}
+
+// Fetch reads a single key's raw value from the bucket at path. It returns
+// a nil value (not an error) when the key itself is absent.
+func Fetch(db *bbolt.DB, path []string, key []byte) ([]byte, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot get values at root level, only buckets")
+	}
+	var val []byte
+	err := db.View(func(tx *bbolt.Tx) error {
+		b := common.BucketAtPath(tx, path)
+		if b == nil {
+			return fmt.Errorf("bucket not found")
+		}
+		val = b.Get(key)
+		return nil
+	})
+	return val, err
+}
+
 func Run() {
-	var dbPath, bucketPath, keyBase64, mode, out string
+	var dbPath, bucketPath, keyBase64, keyHex, keyUint64, mode, out, codec string
 	var n int
 	flag.StringVar(&dbPath, "db", "", "DB path")
 	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
 	flag.StringVar(&keyBase64, "key", "", "key (base64)")
+	flag.StringVar(&keyHex, "key-hex", "", "key (hex), alternative to -key")
+	flag.StringVar(&keyUint64, "key-uint64", "", "key as a big-endian uint64, alternative to -key")
 	flag.StringVar(&mode, "mode", "head", "mode: head|save|pipe")
 	flag.IntVar(&n, "n", 65536, "bytes for head")
 	flag.StringVar(&out, "out", "", "output file (for save)")
+	flag.StringVar(&codec, "codec", "", "decode the value with this codec before display (auto probes, empty skips)")
 	flag.Parse()
-	if dbPath == "" || keyBase64 == "" {
+	if dbPath == "" {
 		fmt.Fprintln(os.Stderr, "missing required args")
 		os.Exit(1)
 	}
+	key, err := resolveKey(keyBase64, keyHex, keyUint64)
+	if err != nil {
+		common.Fail("key", err)
+	}
 	var path []string
 	if bucketPath != "" {
 		path = strings.Split(bucketPath, "/")
 	}
-	key, _ := base64.StdEncoding.DecodeString(keyBase64)
 	db, err := common.OpenDB(dbPath)
 	if err != nil {
 		common.Fail("open db", err)
 	}
 	defer db.Close()
-	var total int
-	var val []byte
-	_ = db.View(func(tx *bbolt.Tx) error {
-		if len(path) == 0 {
-			// Root level - cannot get values directly, only buckets exist at root
-			return fmt.Errorf("cannot get values at root level, only buckets")
-		} else {
-			b := common.BucketAtPath(tx, path)
-			if b == nil {
-				return fmt.Errorf("bucket not found")
-			}
-			val = b.Get(key)
-		}
-		if val != nil {
-			total = len(val)
-		}
-		return nil
-	})
+	val, _ := Fetch(db, path, key)
+	total := len(val)
 	switch mode {
 	case "head":
 		head := val
 		if len(val) > n {
 			head = val[:n]
 		}
-		res := HeadResult{"head", total, base64.StdEncoding.EncodeToString(head)}
+		res := HeadResult{Mode: "head", TotalSize: total, ValueHeadBase64: base64.StdEncoding.EncodeToString(head)}
+		if codec != "" {
+			if _, text, used, err := common.DecodeValue(codec, val); err == nil {
+				res.Codec = used
+				res.DecodedText = text
+			}
+		}
 		_ = json.NewEncoder(os.Stdout).Encode(res)
 	case "save":
 		f, err := os.Create(out)
@@ -0,0 +1,296 @@
+// Package importer replays an export.WriteEvents stream back into a bolt
+// file, the inverse of the export subcommand.
+package importer
+
+import (
+	"bolthelper/internal/common"
+	"bolthelper/internal/export"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"go.etcd.io/bbolt"
+)
+
+type Result struct {
+	Ok             bool `json:"ok"`
+	DryRun         bool `json:"dryRun,omitempty"`
+	BucketsCreated int  `json:"bucketsCreated"`
+	KeysWritten    int  `json:"keysWritten"`
+	KeysDeleted    int  `json:"keysDeleted,omitempty"`
+}
+
+// frame tracks one open bucket while replaying a stream, so bucket_end
+// can apply --delete-missing against exactly the keys/buckets this import
+// actually wrote.
+type frame struct {
+	bucket *bbolt.Bucket
+	seen   map[string]bool
+}
+
+func Run() {
+	var dbPath, in, mode string
+	var deleteMissing, gzipIn bool
+	flag.StringVar(&dbPath, "db", "", "DB path")
+	flag.StringVar(&in, "in", "", "input file (an export stream)")
+	flag.StringVar(&mode, "mode", "merge", "mode: replace|merge|dry-run")
+	flag.BoolVar(&deleteMissing, "delete-missing", false, "delete existing keys/buckets absent from the import, for true snapshot restore")
+	flag.BoolVar(&gzipIn, "gzip", false, "input stream is gzip-compressed")
+	flag.Parse()
+
+	if dbPath == "" || in == "" {
+		fmt.Fprintln(os.Stderr, "missing required args")
+		os.Exit(1)
+	}
+	switch mode {
+	case "replace", "merge", "dry-run":
+	default:
+		common.Fail("import", fmt.Errorf("invalid -mode %q, want replace|merge|dry-run", mode))
+	}
+
+	f, err := os.Open(in)
+	if err != nil {
+		common.Fail("open in", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if gzipIn {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			common.Fail("open gzip", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	if mode == "dry-run" {
+		res, err := dryRun(dbPath, r, deleteMissing)
+		if err != nil {
+			common.Fail("import", err)
+		}
+		json.NewEncoder(os.Stdout).Encode(res)
+		return
+	}
+
+	db, err := bbolt.Open(dbPath, 0644, nil)
+	if err != nil {
+		common.Fail("open db", err)
+	}
+	defer db.Close()
+
+	var res Result
+	err = db.Update(func(tx *bbolt.Tx) error {
+		var stack []frame
+		dec := json.NewDecoder(r)
+		for {
+			var ev export.Event
+			if err := dec.Decode(&ev); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+			if err := applyEvent(tx, &stack, ev, mode, deleteMissing, &res); err != nil {
+				return err
+			}
+		}
+		if len(stack) != 0 {
+			return fmt.Errorf("truncated import stream: %d bucket(s) left open", len(stack))
+		}
+		return nil
+	})
+	if err != nil {
+		common.Fail("import", err)
+	}
+	res.Ok = true
+	json.NewEncoder(os.Stdout).Encode(res)
+}
+
+func applyEvent(tx *bbolt.Tx, stack *[]frame, ev export.Event, mode string, deleteMissing bool, res *Result) error {
+	switch ev.Type {
+	case "bucket_start":
+		var parent *bbolt.Bucket
+		var existed bool
+		if len(*stack) > 0 {
+			parent = (*stack)[len(*stack)-1].bucket
+			(*stack)[len(*stack)-1].seen[ev.Name] = true
+			existed = parent.Bucket([]byte(ev.Name)) != nil
+		} else {
+			existed = tx.Bucket([]byte(ev.Name)) != nil
+		}
+		b, err := createOrReuseBucket(tx, parent, ev.Name, mode)
+		if err != nil {
+			return fmt.Errorf("bucket %q: %w", ev.Name, err)
+		}
+		if !existed {
+			res.BucketsCreated++
+		}
+		*stack = append(*stack, frame{bucket: b, seen: map[string]bool{}})
+	case "kv":
+		if len(*stack) == 0 {
+			return fmt.Errorf("kv event outside any bucket")
+		}
+		top := &(*stack)[len(*stack)-1]
+		key, err := base64.StdEncoding.DecodeString(ev.KeyBase64)
+		if err != nil {
+			return fmt.Errorf("invalid keyBase64: %w", err)
+		}
+		value, err := base64.StdEncoding.DecodeString(ev.ValueBase64)
+		if err != nil {
+			return fmt.Errorf("invalid valueBase64: %w", err)
+		}
+		if err := top.bucket.Put(key, value); err != nil {
+			return err
+		}
+		top.seen[string(key)] = true
+		res.KeysWritten++
+	case "bucket_end":
+		if len(*stack) == 0 {
+			return fmt.Errorf("bucket_end with no open bucket")
+		}
+		top := (*stack)[len(*stack)-1]
+		*stack = (*stack)[:len(*stack)-1]
+		if deleteMissing {
+			n, err := deleteUnseen(top.bucket, top.seen)
+			if err != nil {
+				return err
+			}
+			res.KeysDeleted += n
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+	return nil
+}
+
+// createOrReuseBucket creates name under parent (or at the root, when
+// parent is nil). In replace mode any existing bucket of the same name is
+// dropped first so the subtree is rebuilt from scratch; merge keeps
+// existing contents and only creates what's missing.
+func createOrReuseBucket(tx *bbolt.Tx, parent *bbolt.Bucket, name, mode string) (*bbolt.Bucket, error) {
+	if mode == "replace" {
+		if parent != nil {
+			parent.DeleteBucket([]byte(name))
+		} else {
+			tx.DeleteBucket([]byte(name))
+		}
+	}
+	if parent != nil {
+		return parent.CreateBucketIfNotExists([]byte(name))
+	}
+	return tx.CreateBucketIfNotExists([]byte(name))
+}
+
+// deleteUnseen removes every key and sub-bucket of b that wasn't named in
+// seen, the --delete-missing half of snapshot-restore semantics.
+func deleteUnseen(b *bbolt.Bucket, seen map[string]bool) (int, error) {
+	stale := unseenKeys(b, seen)
+	for _, k := range stale {
+		if b.Get(k) == nil {
+			if err := b.DeleteBucket(k); err != nil {
+				return 0, err
+			}
+		} else if err := b.Delete(k); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
+
+// unseenKeys returns every key and sub-bucket name of b that wasn't named
+// in seen, shared by deleteUnseen (which removes them) and dryRun (which
+// only needs the count).
+func unseenKeys(b *bbolt.Bucket, seen map[string]bool) [][]byte {
+	var stale [][]byte
+	_ = b.ForEach(func(k, v []byte) error {
+		if !seen[string(k)] {
+			stale = append(stale, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	return stale
+}
+
+// dryFrame tracks one open bucket while previewing a stream, mirroring
+// frame so dry-run can report the same --delete-missing counts a real
+// merge run would produce.
+type dryFrame struct {
+	path []string
+	seen map[string]bool
+}
+
+// dryRun validates the stream against the existing DB, without writing
+// anything, reporting the same counts a real merge would produce,
+// including keysDeleted when deleteMissing is set. A replace run can
+// report different counts, since it drops existing buckets before
+// recreating them.
+func dryRun(dbPath string, r io.Reader, deleteMissing bool) (Result, error) {
+	db, err := common.OpenDB(dbPath)
+	if err != nil {
+		return Result{}, err
+	}
+	defer db.Close()
+
+	res := Result{DryRun: true}
+	err = db.View(func(tx *bbolt.Tx) error {
+		var stack []dryFrame
+		dec := json.NewDecoder(r)
+		for {
+			var ev export.Event
+			if err := dec.Decode(&ev); err != nil {
+				if err == io.EOF {
+					break
+				}
+				return err
+			}
+			switch ev.Type {
+			case "bucket_start":
+				path := append(append([]string{}, ev.Path...), ev.Name)
+				if common.BucketAtPath(tx, path) == nil {
+					res.BucketsCreated++
+				}
+				if len(stack) > 0 {
+					top := &stack[len(stack)-1]
+					top.seen[ev.Name] = true
+				}
+				stack = append(stack, dryFrame{path: path, seen: map[string]bool{}})
+			case "kv":
+				if len(stack) == 0 {
+					return fmt.Errorf("kv event outside any bucket")
+				}
+				key, err := base64.StdEncoding.DecodeString(ev.KeyBase64)
+				if err != nil {
+					return fmt.Errorf("invalid keyBase64: %w", err)
+				}
+				stack[len(stack)-1].seen[string(key)] = true
+				res.KeysWritten++
+			case "bucket_end":
+				if len(stack) == 0 {
+					return fmt.Errorf("bucket_end with no open bucket")
+				}
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				if deleteMissing {
+					if b := common.BucketAtPath(tx, top.path); b != nil {
+						res.KeysDeleted += len(unseenKeys(b, top.seen))
+					}
+				}
+			default:
+				return fmt.Errorf("unknown event type %q", ev.Type)
+			}
+		}
+		if len(stack) != 0 {
+			return fmt.Errorf("truncated import stream: %d bucket(s) left open", len(stack))
+		}
+		return nil
+	})
+	if err == nil {
+		res.Ok = true
+	}
+	return res, err
+}
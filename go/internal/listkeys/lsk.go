@@ -13,9 +13,10 @@ import (
 )
 
 type Item struct {
-	KeyBase64 string `json:"keyBase64"`
-	ValueSize int    `json:"valueSize"`
-	IsBucket  bool   `json:"isBucket"`
+	KeyBase64  string `json:"keyBase64"`
+	KeyDecoded string `json:"keyDecoded,omitempty"`
+	ValueSize  int    `json:"valueSize"`
+	IsBucket   bool   `json:"isBucket"`
 }
 
 type Result struct {
@@ -24,28 +25,11 @@ type Result struct {
 	ApproxReturned int    `json:"approxReturned"`
 }
 
-func Run() {
-	var dbPath, bucketPath, prefix, afterKey string
-	var limit int
-	flag.StringVar(&dbPath, "db", "", "DB path")
-	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
-	flag.StringVar(&prefix, "prefix", "", "prefix filter")
-	flag.IntVar(&limit, "limit", 1000, "max keys")
-	flag.StringVar(&afterKey, "after-key", "", "resume after key (base64)")
-	flag.Parse()
-	if dbPath == "" {
-		fmt.Fprintln(os.Stderr, "missing required args")
-		os.Exit(1)
-	}
-	var path []string
-	if bucketPath != "" {
-		path = strings.Split(bucketPath, "/")
-	}
-	db, err := common.OpenDB(dbPath)
-	if err != nil {
-		common.Fail("open db", err)
-	}
-	defer db.Close()
+// List enumerates keys (or top-level buckets, at the root) under path,
+// applying prefix filtering and after-key pagination. When profile has a
+// rule for path, each key is additionally rendered into KeyDecoded.
+func List(db *bbolt.DB, path []string, prefix, afterKey string, limit int, profile *common.KeyProfile) Result {
+	keyType := profile.TypeFor(path)
 	var res Result
 	db.View(func(tx *bbolt.Tx) error {
 		var b *bbolt.Bucket
@@ -94,6 +78,9 @@ func Run() {
 					ValueSize: bucket.size,
 					IsBucket:  true,
 				}
+				if decoded, ok := common.DecodeKey(keyType, bucket.name); ok {
+					item.KeyDecoded = decoded
+				}
 				res.Items = append(res.Items, item)
 				count++
 			}
@@ -132,6 +119,9 @@ func Run() {
 				break
 			}
 			item := Item{KeyBase64: base64.StdEncoding.EncodeToString(k), ValueSize: len(v), IsBucket: v == nil}
+			if decoded, ok := common.DecodeKey(keyType, k); ok {
+				item.KeyDecoded = decoded
+			}
 			res.Items = append(res.Items, item)
 			count++
 		}
@@ -139,8 +129,38 @@ func Run() {
 			res.NextAfterKey = nextAfterKey
 		}
 		res.ApproxReturned = count
-		fmt.Fprintf(os.Stderr, "[lsk.go] Returned %d items, NextAfterKey: %s\n", count, res.NextAfterKey)
 		return nil
 	})
+	return res
+}
+
+func Run() {
+	var dbPath, bucketPath, prefix, afterKey, profileSpec string
+	var limit int
+	flag.StringVar(&dbPath, "db", "", "DB path")
+	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
+	flag.StringVar(&prefix, "prefix", "", "prefix filter")
+	flag.IntVar(&limit, "limit", 1000, "max keys")
+	flag.StringVar(&afterKey, "after-key", "", "resume after key (base64)")
+	flag.StringVar(&profileSpec, "profile", "", "key profile: inline pathGlob=type rules, or a JSON/YAML file")
+	flag.Parse()
+	if dbPath == "" {
+		fmt.Fprintln(os.Stderr, "missing required args")
+		os.Exit(1)
+	}
+	var path []string
+	if bucketPath != "" {
+		path = strings.Split(bucketPath, "/")
+	}
+	profile, err := common.LoadKeyProfile(profileSpec)
+	if err != nil {
+		common.Fail("load profile", err)
+	}
+	db, err := common.OpenDB(dbPath)
+	if err != nil {
+		common.Fail("open db", err)
+	}
+	defer db.Close()
+	res := List(db, path, prefix, afterKey, limit, profile)
 	json.NewEncoder(os.Stdout).Encode(res)
 }
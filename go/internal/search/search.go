@@ -3,43 +3,275 @@ package search
 import (
 	"bolthelper/internal/common"
 	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"go.etcd.io/bbolt"
 )
 
 type SearchItem struct {
-	Path      []string `json:"path"`
-	KeyBase64 string   `json:"keyBase64"`
-	ValueSize int      `json:"valueSize"`
-	IsBucket  bool     `json:"isBucket"`
-	Type      string   `json:"type"` // "bucket" or "key"
+	Path         []string `json:"path"`
+	KeyBase64    string   `json:"keyBase64"`
+	KeyDecoded   string   `json:"keyDecoded,omitempty"`
+	ValueSize    int      `json:"valueSize"`
+	IsBucket     bool     `json:"isBucket"`
+	Type         string   `json:"type"`                // "bucket" or "key"
+	MatchedIn    string   `json:"matchedIn,omitempty"`  // "key" or "value"
+	MatchOffset  int      `json:"matchOffset,omitempty"`
+	MatchSnippet string   `json:"matchSnippet,omitempty"` // base64, a window of text around the match
 }
 
 type SearchResult struct {
 	Items   []SearchItem `json:"items"`
 	Total   int          `json:"total"`
 	Limited bool         `json:"limited"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// Options controls a single Search call. Mode selects how Query is
+// interpreted; In selects whether it's matched against key names, decoded
+// values, or both (jsonpath always matches against values); Codec (if
+// set) decodes each value with common.DecodeValue before matching so the
+// query can reach inside serialized structs; Workers > 1 scans top-level
+// buckets concurrently, each under its own read transaction.
+type Options struct {
+	Query         string
+	Mode          string // "substring" (default) | "regex" | "glob" | "jsonpath"
+	JSONPath      string // field path for Mode "jsonpath", e.g. "user.id" or "items[0].name"
+	CaseSensitive bool
+	Limit         int
+	In            string // "key" | "value" | "both"
+	Codec         string
+	Profile       *common.KeyProfile
+	Workers       int
+
+	re *regexp.Regexp // compiled lazily by prepare, only for Mode "regex" and "glob"
+}
+
+func (o Options) matchesKey() bool {
+	return o.Mode != "jsonpath" && (o.In == "" || o.In == "key" || o.In == "both")
+}
+
+func (o Options) matchesValue() bool {
+	return o.Mode == "jsonpath" || o.In == "value" || o.In == "both"
+}
+
+// prepare fills in defaults, normalizes Query's case, and compiles a
+// regex for Mode "regex" and "glob", returning the Options ready to pass
+// down the walk.
+func (o Options) prepare() (Options, error) {
+	if o.Mode == "" {
+		o.Mode = "substring"
+	}
+	if o.Limit <= 0 {
+		o.Limit = 100
+	}
+	if o.Workers <= 0 {
+		o.Workers = 1
+	}
+	if !o.CaseSensitive {
+		o.Query = strings.ToLower(o.Query)
+	}
+	switch o.Mode {
+	case "regex":
+		expr := o.Query
+		if !o.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return o, fmt.Errorf("invalid regex: %w", err)
+		}
+		o.re = re
+	case "glob":
+		expr := globToRegex(o.Query)
+		if !o.CaseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return o, fmt.Errorf("invalid glob: %w", err)
+		}
+		o.re = re
+	}
+	return o, nil
+}
+
+// match reports whether s satisfies Query per Mode, along with the byte
+// offset of the match within s and a short base64-encoded window of s
+// around it.
+func (o Options) match(s string) (offset int, snippet string, ok bool) {
+	switch o.Mode {
+	case "regex", "glob":
+		loc := o.re.FindStringIndex(s)
+		if loc == nil {
+			return 0, "", false
+		}
+		return loc[0], window(s, loc[0], loc[1]), true
+	default: // substring, and the string rendering used by jsonpath
+		cmp := s
+		if !o.CaseSensitive {
+			cmp = strings.ToLower(cmp)
+		}
+		idx := strings.Index(cmp, o.Query)
+		if idx < 0 {
+			return 0, "", false
+		}
+		return idx, window(s, idx, idx+len(o.Query)), true
+	}
+}
+
+// globToRegex translates a shell-style glob ("*" any run of characters
+// including "/", "?" a single character, "[...]" a character class) into
+// an anchored regexp source, so matching doesn't inherit path.Match's
+// rule that "*" stops at "/" and so a malformed pattern surfaces the same
+// way an invalid -mode=regex expression does, via regexp.Compile's error.
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			j := i + 1
+			if j < len(glob) && (glob[j] == '!' || glob[j] == '^') {
+				j++
+			}
+			if j < len(glob) && glob[j] == ']' {
+				j++
+			}
+			for j < len(glob) && glob[j] != ']' {
+				j++
+			}
+			if j >= len(glob) {
+				// unterminated class - let regexp.Compile report it
+				b.WriteByte('[')
+				continue
+			}
+			cls := strings.Replace(glob[i+1:j], "!", "^", 1)
+			b.WriteByte('[')
+			b.WriteString(cls)
+			b.WriteByte(']')
+			i = j
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteByte('$')
+	return b.String()
+}
+
+// window returns a base64-encoded slice of s centered on [from, to), with
+// a little surrounding context on each side.
+func window(s string, from, to int) string {
+	const pad = 20
+	start, end := from-pad, to+pad
+	if start < 0 {
+		start = 0
+	}
+	if end > len(s) {
+		end = len(s)
+	}
+	return base64.StdEncoding.EncodeToString([]byte(s[start:end]))
+}
+
+// matchValue decodes v per Codec (and, for Mode "jsonpath", extracts
+// JSONPath before matching) and checks the result against Query.
+func (o Options) matchValue(v []byte) (offset int, snippet string, ok bool) {
+	text := string(v)
+	if o.Codec != "" {
+		if _, decodedText, _, err := common.DecodeValue(o.Codec, v); err == nil {
+			text = decodedText
+		}
+	}
+	if o.Mode != "jsonpath" {
+		return o.match(text)
+	}
+	var doc any
+	if err := json.Unmarshal([]byte(text), &doc); err != nil {
+		return 0, "", false
+	}
+	field, found := jsonPathLookup(doc, o.JSONPath)
+	if !found {
+		return 0, "", false
+	}
+	return o.match(fmt.Sprint(field))
+}
+
+// Search walks every bucket in db looking for names (and, with
+// Options.In including "value" or Options.Mode "jsonpath", decoded value
+// contents) that match Options.Query, stopping once Options.Limit matches
+// have been collected. With Options.Workers > 1, top-level buckets are
+// scanned concurrently, each under its own read transaction.
+func Search(db *bbolt.DB, opts Options) SearchResult {
+	opts, err := opts.prepare()
+	if err != nil {
+		return SearchResult{Error: err.Error()}
+	}
+
+	var items []SearchItem
+	var mu sync.Mutex
+	var count int64
+
+	if opts.Workers > 1 {
+		searchParallel(db, opts, &items, &mu, &count)
+		// workers check the limit before appending, so a few may overshoot it
+		if len(items) > opts.Limit {
+			items = items[:opts.Limit]
+		}
+	} else {
+		db.View(func(tx *bbolt.Tx) error {
+			return searchRecursive(tx, nil, opts, &items, &mu, &count)
+		})
+	}
+
+	return SearchResult{
+		Items:   items,
+		Total:   len(items),
+		Limited: limitReached(&count, opts.Limit),
+	}
 }
 
 func Run() {
-	var dbPath, query string
-	var limit int
+	var dbPath, query, in, codec, profileSpec, mode, jsonpath string
+	var limit, workers int
 	var caseSensitive bool
 
 	flag.StringVar(&dbPath, "db", "", "DB path")
 	flag.StringVar(&query, "query", "", "Search query")
 	flag.IntVar(&limit, "limit", 100, "Maximum number of results")
 	flag.BoolVar(&caseSensitive, "case-sensitive", false, "Case sensitive search")
+	flag.StringVar(&in, "in", "key", "where to match: key|value|both")
+	flag.StringVar(&codec, "codec", "", "decode values with this codec before matching (auto probes, empty skips)")
+	flag.StringVar(&profileSpec, "profile", "", "key profile: inline pathGlob=type rules, or a JSON/YAML file")
+	flag.StringVar(&mode, "mode", "substring", "match mode: substring|regex|glob|jsonpath")
+	flag.StringVar(&jsonpath, "jsonpath", "", "field path for -mode=jsonpath, e.g. user.id or items[0].name")
+	flag.IntVar(&workers, "workers", 1, "scan this many top-level buckets concurrently")
 	flag.Parse()
 
 	if dbPath == "" || query == "" {
 		fmt.Fprintln(os.Stderr, "missing required args: db and query")
 		os.Exit(1)
 	}
+	if mode == "jsonpath" && jsonpath == "" {
+		fmt.Fprintln(os.Stderr, "-mode=jsonpath requires -jsonpath")
+		os.Exit(1)
+	}
+
+	profile, err := common.LoadKeyProfile(profileSpec)
+	if err != nil {
+		common.Fail("load profile", err)
+	}
 
 	db, err := common.OpenDB(dbPath)
 	if err != nil {
@@ -47,113 +279,233 @@ func Run() {
 	}
 	defer db.Close()
 
-	var results []SearchItem
-	var count int
+	common.PrintJSON(Search(db, Options{
+		Query:         query,
+		Mode:          mode,
+		JSONPath:      jsonpath,
+		CaseSensitive: caseSensitive,
+		Limit:         limit,
+		In:            in,
+		Codec:         codec,
+		Profile:       profile,
+		Workers:       workers,
+	}))
+}
 
-	searchQuery := query
-	if !caseSensitive {
-		searchQuery = strings.ToLower(searchQuery)
-	}
+func limitReached(count *int64, limit int) bool {
+	return atomic.LoadInt64(count) >= int64(limit)
+}
 
+func appendItem(items *[]SearchItem, mu *sync.Mutex, count *int64, item SearchItem) {
+	mu.Lock()
+	*items = append(*items, item)
+	mu.Unlock()
+	atomic.AddInt64(count, 1)
+}
+
+// searchParallel enumerates top-level buckets under one short read
+// transaction, then hands each to a worker goroutine with its own
+// db.View (bbolt allows concurrent readers), capped at Options.Workers
+// concurrent scans and Options.Limit total matches.
+func searchParallel(db *bbolt.DB, opts Options, items *[]SearchItem, mu *sync.Mutex, count *int64) {
+	var topNames [][]byte
 	db.View(func(tx *bbolt.Tx) error {
-		return searchRecursive(tx, []string{}, searchQuery, caseSensitive, limit, &results, &count)
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if b != nil {
+				topNames = append(topNames, append([]byte(nil), name...))
+			}
+			return nil
+		})
 	})
 
-	response := SearchResult{
-		Items:   results,
-		Total:   len(results),
-		Limited: len(results) >= limit,
+	sem := make(chan struct{}, opts.Workers)
+	var wg sync.WaitGroup
+	for _, name := range topNames {
+		if limitReached(count, opts.Limit) {
+			break
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if limitReached(count, opts.Limit) {
+				return
+			}
+			db.View(func(tx *bbolt.Tx) error {
+				considerBucketName(name, opts, items, mu, count)
+				b := tx.Bucket(name)
+				if b == nil || limitReached(count, opts.Limit) {
+					return nil
+				}
+				return searchInBucket(b, []string{string(name)}, opts, items, mu, count)
+			})
+		}(name)
 	}
-	common.PrintJSON(response)
+	wg.Wait()
 }
 
-func searchRecursive(tx *bbolt.Tx, path []string, query string, caseSensitive bool, limit int, items *[]SearchItem, count *int) error {
-	if *count >= limit {
+func searchRecursive(tx *bbolt.Tx, path []string, opts Options, items *[]SearchItem, mu *sync.Mutex, count *int64) error {
+	if limitReached(count, opts.Limit) {
 		return nil
 	}
 
 	if len(path) == 0 {
-		// Root level - search through top-level buckets
 		return tx.ForEach(func(name []byte, bucket *bbolt.Bucket) error {
-			if *count >= limit {
+			if limitReached(count, opts.Limit) {
 				return nil
 			}
-
-			keyStr := string(name)
-			searchKey := keyStr
-			if !caseSensitive {
-				searchKey = strings.ToLower(keyStr)
-			}
-
-			// Check if bucket name matches search query
-			if strings.Contains(searchKey, query) {
-				item := SearchItem{
-					Path:      []string{},
-					KeyBase64: base64.StdEncoding.EncodeToString(name),
-					ValueSize: 0, // Bucket size calculation is expensive, skip for search
-					IsBucket:  true,
-					Type:      "bucket",
-				}
-				*items = append(*items, item)
-				*count++
-			}
-
-			// Search recursively in this bucket
-			if *count < limit && bucket != nil {
-				newPath := []string{keyStr}
-				return searchInBucket(bucket, newPath, query, caseSensitive, limit, items, count)
+			considerBucketName(name, opts, items, mu, count)
+			if bucket != nil && !limitReached(count, opts.Limit) {
+				return searchInBucket(bucket, []string{string(name)}, opts, items, mu, count)
 			}
-
 			return nil
 		})
-	} else {
-		// Search in specific bucket
-		bucket := common.BucketAtPath(tx, path)
-		if bucket == nil {
-			return nil
-		}
-		return searchInBucket(bucket, path, query, caseSensitive, limit, items, count)
 	}
+
+	bucket := common.BucketAtPath(tx, path)
+	if bucket == nil {
+		return nil
+	}
+	return searchInBucket(bucket, path, opts, items, mu, count)
 }
 
-func searchInBucket(bucket *bbolt.Bucket, path []string, query string, caseSensitive bool, limit int, items *[]SearchItem, count *int) error {
+// considerBucketName checks a top-level bucket's own name against Query,
+// the same as a "key" match on a nested bucket.
+func considerBucketName(name []byte, opts Options, items *[]SearchItem, mu *sync.Mutex, count *int64) {
+	if !opts.matchesKey() {
+		return
+	}
+	offset, snippet, ok := opts.match(string(name))
+	if !ok {
+		return
+	}
+	item := SearchItem{
+		KeyBase64:    base64.StdEncoding.EncodeToString(name),
+		IsBucket:     true,
+		Type:         "bucket",
+		MatchedIn:    "key",
+		MatchOffset:  offset,
+		MatchSnippet: snippet,
+	}
+	if decoded, ok := common.DecodeKey(opts.Profile.TypeFor(nil), name); ok {
+		item.KeyDecoded = decoded
+	}
+	appendItem(items, mu, count, item)
+}
+
+func searchInBucket(bucket *bbolt.Bucket, path []string, opts Options, items *[]SearchItem, mu *sync.Mutex, count *int64) error {
 	return bucket.ForEach(func(k, v []byte) error {
-		if *count >= limit {
+		if limitReached(count, opts.Limit) {
 			return nil
 		}
 
-		keyStr := string(k)
-		searchKey := keyStr
-		if !caseSensitive {
-			searchKey = strings.ToLower(keyStr)
+		matched, matchedIn, offset, snippet := false, "", 0, ""
+		if opts.matchesKey() {
+			if o, s, ok := opts.match(string(k)); ok {
+				matched, matchedIn, offset, snippet = true, "key", o, s
+			}
+		}
+		if !matched && v != nil && opts.matchesValue() {
+			if o, s, ok := opts.matchValue(v); ok {
+				matched, matchedIn, offset, snippet = true, "value", o, s
+			}
 		}
 
-		// Check if key matches search query
-		if strings.Contains(searchKey, query) {
+		if matched {
 			item := SearchItem{
-				Path:      append([]string{}, path...),
-				KeyBase64: base64.StdEncoding.EncodeToString(k),
-				ValueSize: len(v),
-				IsBucket:  v == nil,
+				Path:         append([]string{}, path...),
+				KeyBase64:    base64.StdEncoding.EncodeToString(k),
+				ValueSize:    len(v),
+				IsBucket:     v == nil,
+				MatchedIn:    matchedIn,
+				MatchOffset:  offset,
+				MatchSnippet: snippet,
 			}
 			if v == nil {
 				item.Type = "bucket"
 			} else {
 				item.Type = "key"
 			}
-			*items = append(*items, item)
-			*count++
+			if decoded, ok := common.DecodeKey(opts.Profile.TypeFor(path), k); ok {
+				item.KeyDecoded = decoded
+			}
+			appendItem(items, mu, count, item)
 		}
 
 		// If this is a bucket, search recursively
-		if v == nil && *count < limit {
-			newPath := append(path, keyStr)
+		if v == nil && !limitReached(count, opts.Limit) {
+			newPath := append(append([]string{}, path...), string(k))
 			subBucket := bucket.Bucket(k)
 			if subBucket != nil {
-				return searchInBucket(subBucket, newPath, query, caseSensitive, limit, items, count)
+				return searchInBucket(subBucket, newPath, opts, items, mu, count)
 			}
 		}
 
 		return nil
 	})
 }
+
+// jsonPathSeg is one step of a parsed JSONPath-lite expression: either a
+// map key (name) or an array index (isIndex).
+type jsonPathSeg struct {
+	name    string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath accepts a small dotted/bracket-index subset of JSONPath -
+// "user.id", "items[0].name", with an optional leading "$." - not the
+// full spec (no wildcards, slices, or filter expressions).
+func parseJSONPath(p string) []jsonPathSeg {
+	p = strings.TrimPrefix(p, "$")
+	p = strings.TrimPrefix(p, ".")
+	var segs []jsonPathSeg
+	for _, part := range strings.Split(p, ".") {
+		for part != "" {
+			if i := strings.IndexByte(part, '['); i >= 0 {
+				if i > 0 {
+					segs = append(segs, jsonPathSeg{name: part[:i]})
+				}
+				j := strings.IndexByte(part, ']')
+				if j < 0 {
+					break
+				}
+				idx, _ := strconv.Atoi(part[i+1 : j])
+				segs = append(segs, jsonPathSeg{index: idx, isIndex: true})
+				part = part[j+1:]
+			} else {
+				segs = append(segs, jsonPathSeg{name: part})
+				part = ""
+			}
+		}
+	}
+	return segs
+}
+
+func jsonPathLookup(doc any, path string) (any, bool) {
+	cur := doc
+	for _, seg := range parseJSONPath(path) {
+		if seg.isIndex {
+			arr, ok := cur.([]any)
+			if !ok || seg.index < 0 || seg.index >= len(arr) {
+				return nil, false
+			}
+			cur = arr[seg.index]
+			continue
+		}
+		if seg.name == "" {
+			continue
+		}
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		v, ok := m[seg.name]
+		if !ok {
+			return nil, false
+		}
+		cur = v
+	}
+	return cur, true
+}
@@ -0,0 +1,180 @@
+package serve
+
+import (
+	"bolthelper/internal/common"
+	"bolthelper/internal/export"
+	"bolthelper/internal/get"
+	"bolthelper/internal/listkeys"
+	"bolthelper/internal/search"
+	"bolthelper/internal/write"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcParams struct {
+	Path          string `json:"path"`
+	Key           string `json:"key"`
+	Value         string `json:"value"`
+	Prefix        string `json:"prefix"`
+	AfterKey      string `json:"afterKey"`
+	Limit         int    `json:"limit"`
+	Query         string `json:"query"`
+	CaseSensitive bool   `json:"caseSensitive"`
+	Op            string `json:"op"`
+	Codec         string `json:"codec"`
+	In            string `json:"in"`
+	Profile       string `json:"profile"`
+	Mode          string `json:"mode"`
+	JSONPath      string `json:"jsonpath"`
+	Workers       int    `json:"workers"`
+}
+
+// runRPC serves one JSON-RPC 2.0 request per line read from r, writing one
+// response object per line to w. It's meant for editor integrations that
+// want to keep a single long-lived process talking over stdio instead of
+// opening a socket.
+func (s *Server) runRPC(r io.Reader, w io.Writer) {
+	dec := json.NewDecoder(r)
+	for {
+		var req rpcRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		resp := s.dispatchRPC(req)
+		enc := json.NewEncoder(w)
+		enc.Encode(resp)
+	}
+}
+
+func (s *Server) dispatchRPC(req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+
+	var p rpcParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			resp.Error = &rpcError{Code: -32602, Message: "invalid params: " + err.Error()}
+			return resp
+		}
+	}
+	var path []string
+	if p.Path != "" {
+		path = strings.Split(p.Path, "/")
+	}
+	profile, err := common.LoadKeyProfile(p.Profile)
+	if err != nil {
+		resp.Error = &rpcError{Code: -32602, Message: "invalid profile: " + err.Error()}
+		return resp
+	}
+
+	switch req.Method {
+	case "meta":
+		s.withRead(func(db *bbolt.DB) { resp.Result = common.CmdMeta(db) })
+	case "lsb":
+		s.withRead(func(db *bbolt.DB) {
+			db.View(func(tx *bbolt.Tx) error {
+				resp.Result = common.CmdListBuckets(tx, path, profile)
+				return nil
+			})
+		})
+	case "lsk":
+		s.withRead(func(db *bbolt.DB) {
+			resp.Result = listkeys.List(db, path, p.Prefix, p.AfterKey, nonZero(p.Limit, 1000), profile)
+		})
+	case "get":
+		key, err := base64.StdEncoding.DecodeString(p.Key)
+		if err != nil {
+			resp.Error = &rpcError{Code: -32602, Message: "invalid key base64"}
+			return resp
+		}
+		s.withRead(func(db *bbolt.DB) {
+			val, err := get.Fetch(db, path, key)
+			if err != nil {
+				resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+				return
+			}
+			res := get.HeadResult{Mode: "head", TotalSize: len(val), ValueHeadBase64: base64.StdEncoding.EncodeToString(val)}
+			if p.Codec != "" {
+				if _, text, used, err := common.DecodeValue(p.Codec, val); err == nil {
+					res.Codec = used
+					res.DecodedText = text
+				}
+			}
+			resp.Result = res
+		})
+	case "export":
+		s.withRead(func(db *bbolt.DB) {
+			var buf bytes.Buffer
+			if err := export.WriteEvents(db, path, p.Prefix, p.Codec, profile, false, &buf); err != nil {
+				resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+				return
+			}
+			var rows []json.RawMessage
+			dec := json.NewDecoder(&buf)
+			for dec.More() {
+				var raw json.RawMessage
+				if err := dec.Decode(&raw); err != nil {
+					break
+				}
+				rows = append(rows, raw)
+			}
+			resp.Result = rows
+		})
+	case "search":
+		s.withRead(func(db *bbolt.DB) {
+			resp.Result = search.Search(db, search.Options{
+				Query:         p.Query,
+				Mode:          p.Mode,
+				JSONPath:      p.JSONPath,
+				CaseSensitive: p.CaseSensitive,
+				Limit:         nonZero(p.Limit, 100),
+				In:            p.In,
+				Codec:         p.Codec,
+				Profile:       profile,
+				Workers:       nonZero(p.Workers, 1),
+			})
+		})
+	case "write":
+		err := s.withWrite(func(db *bbolt.DB) error {
+			return write.Execute(db, p.Op, p.Path, p.Key, p.Value)
+		})
+		if err != nil {
+			resp.Error = &rpcError{Code: -32000, Message: err.Error()}
+			return resp
+		}
+		resp.Result = map[string]bool{"ok": true}
+	default:
+		resp.Error = &rpcError{Code: -32601, Message: "unknown method: " + req.Method}
+	}
+	return resp
+}
+
+func nonZero(v, def int) int {
+	if v == 0 {
+		return def
+	}
+	return v
+}
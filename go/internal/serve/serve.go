@@ -0,0 +1,291 @@
+// Package serve exposes the subcommands as a long-lived HTTP server over a
+// single bolt file handle, so a caller doesn't pay bbolt.Open's mmap/meta
+// page cost on every request the way the one-shot subcommands do.
+package serve
+
+import (
+	"bolthelper/internal/common"
+	"bolthelper/internal/export"
+	"bolthelper/internal/get"
+	"bolthelper/internal/listkeys"
+	"bolthelper/internal/search"
+	"bolthelper/internal/write"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// Server holds a single bolt handle shared across requests. When rw is
+// false the handle is read-only and write requests promote to a fresh RW
+// handle for the duration of the call, mirroring write.Run. When rw is
+// true the handle is opened read-write up front and every request is
+// routed through mu, mapping reads to db.View and writes to db.Update.
+type Server struct {
+	mu     sync.RWMutex
+	dbPath string
+	db     *bbolt.DB
+	rw     bool
+}
+
+func newServer(dbPath string, rw bool) (*Server, error) {
+	var db *bbolt.DB
+	var err error
+	if rw {
+		db, err = bbolt.Open(dbPath, 0644, nil)
+	} else {
+		db, err = common.OpenDB(dbPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Server{dbPath: dbPath, db: db, rw: rw}, nil
+}
+
+func (s *Server) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.db.Close()
+}
+
+// withRead runs fn against the shared handle under a read lock.
+func (s *Server) withRead(fn func(db *bbolt.DB)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	fn(s.db)
+}
+
+// withWrite runs fn against a handle capable of db.Update. In -rw mode
+// that's the shared handle under the write lock; otherwise the read-only
+// handle is closed, a fresh RW handle is opened for the call, and the
+// read-only handle is reopened afterwards.
+func (s *Server) withWrite(fn func(db *bbolt.DB) error) error {
+	if s.rw {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return fn(s.db)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.db.Close(); err != nil {
+		return err
+	}
+	rwDB, err := bbolt.Open(s.dbPath, 0644, nil)
+	if err != nil {
+		if db, reopenErr := common.OpenDB(s.dbPath); reopenErr == nil {
+			s.db = db
+		}
+		return err
+	}
+	callErr := fn(rwDB)
+	rwDB.Close()
+
+	db, err := common.OpenDB(s.dbPath)
+	if err != nil {
+		return err
+	}
+	s.db = db
+	return callErr
+}
+
+func pathParam(r *http.Request) []string {
+	p := r.URL.Query().Get("path")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
+
+// profileParam loads the key profile named by the "profile" query
+// parameter, if any. A nil profile is a valid, no-op result.
+func profileParam(r *http.Request) (*common.KeyProfile, error) {
+	return common.LoadKeyProfile(r.URL.Query().Get("profile"))
+}
+
+func (s *Server) handleMeta(w http.ResponseWriter, r *http.Request) {
+	s.withRead(func(db *bbolt.DB) {
+		common.PrintJSONTo(w, common.CmdMeta(db))
+	})
+}
+
+func (s *Server) handleLsb(w http.ResponseWriter, r *http.Request) {
+	profile, err := profileParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.withRead(func(db *bbolt.DB) {
+		db.View(func(tx *bbolt.Tx) error {
+			common.PrintJSONTo(w, common.CmdListBuckets(tx, pathParam(r), profile))
+			return nil
+		})
+	})
+}
+
+// handleLsk streams one NDJSON line per item followed by a trailing
+// {"nextAfterKey":...} line, so a client can page without buffering the
+// whole response the way the lsk subcommand's single JSON object would.
+func (s *Server) handleLsk(w http.ResponseWriter, r *http.Request) {
+	limit := 1000
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	profile, err := profileParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	s.withRead(func(db *bbolt.DB) {
+		res := listkeys.List(db, pathParam(r), r.URL.Query().Get("prefix"), r.URL.Query().Get("after-key"), limit, profile)
+		enc := json.NewEncoder(w)
+		for _, item := range res.Items {
+			enc.Encode(item)
+		}
+		enc.Encode(map[string]any{"nextAfterKey": res.NextAfterKey, "approxReturned": res.ApproxReturned})
+	})
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	key, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("key"))
+	if err != nil {
+		http.Error(w, "invalid key base64", http.StatusBadRequest)
+		return
+	}
+	codec := r.URL.Query().Get("codec")
+	s.withRead(func(db *bbolt.DB) {
+		val, err := get.Fetch(db, pathParam(r), key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		res := get.HeadResult{Mode: "head", TotalSize: len(val), ValueHeadBase64: base64.StdEncoding.EncodeToString(val)}
+		if codec != "" {
+			if _, text, used, err := common.DecodeValue(codec, val); err == nil {
+				res.Codec = used
+				res.DecodedText = text
+			}
+		}
+		common.PrintJSONTo(w, res)
+	})
+}
+
+// handleExport streams NDJSON events directly from export.WriteEvents
+// rather than building the Result in memory first.
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	profile, err := profileParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	s.withRead(func(db *bbolt.DB) {
+		q := r.URL.Query()
+		if err := export.WriteEvents(db, pathParam(r), q.Get("prefix"), q.Get("codec"), profile, q.Get("gzip") == "true", w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 100
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			limit = n
+		}
+	}
+	profile, err := profileParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	workers := 1
+	if v := q.Get("workers"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			workers = n
+		}
+	}
+	opts := search.Options{
+		Query:         q.Get("query"),
+		Mode:          q.Get("mode"),
+		JSONPath:      q.Get("jsonpath"),
+		CaseSensitive: q.Get("case-sensitive") == "true",
+		Limit:         limit,
+		In:            q.Get("in"),
+		Codec:         q.Get("codec"),
+		Profile:       profile,
+		Workers:       workers,
+	}
+	s.withRead(func(db *bbolt.DB) {
+		common.PrintJSONTo(w, search.Search(db, opts))
+	})
+}
+
+func (s *Server) handleWrite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "write requires POST", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	err := s.withWrite(func(db *bbolt.DB) error {
+		return write.Execute(db, q.Get("op"), q.Get("path"), q.Get("key"), q.Get("value"))
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	common.PrintJSONTo(w, common.Result{"ok": true})
+}
+
+// Run starts the HTTP server, or the JSON-RPC-over-stdio loop if -rpc is
+// set. Both share the same Server, so editor integrations that prefer a
+// single process-lifetime stdio channel don't need a port at all.
+func Run() {
+	var dbPath, addr string
+	var rw, rpc bool
+	flag.StringVar(&dbPath, "db", "", "DB path")
+	flag.StringVar(&addr, "addr", ":8080", "listen address")
+	flag.BoolVar(&rw, "rw", false, "open a single read-write handle instead of promoting per write")
+	flag.BoolVar(&rpc, "rpc", false, "serve JSON-RPC 2.0 over stdio instead of HTTP")
+	flag.Parse()
+	if dbPath == "" {
+		fmt.Fprintln(os.Stderr, "missing -db")
+		os.Exit(1)
+	}
+
+	s, err := newServer(dbPath, rw)
+	if err != nil {
+		common.Fail("open db", err)
+	}
+	defer s.Close()
+
+	if rpc {
+		s.runRPC(os.Stdin, os.Stdout)
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/meta", s.handleMeta)
+	mux.HandleFunc("/lsb", s.handleLsb)
+	mux.HandleFunc("/lsk", s.handleLsk)
+	mux.HandleFunc("/get", s.handleGet)
+	mux.HandleFunc("/export", s.handleExport)
+	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/write", s.handleWrite)
+
+	fmt.Fprintf(os.Stderr, "serving %s on %s\n", dbPath, addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		common.Fail("serve", err)
+	}
+}
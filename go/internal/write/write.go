@@ -10,12 +10,31 @@ import (
 	"go.etcd.io/bbolt"
 )
 
+// Execute applies a single write operation (create-bucket, put, delete-key,
+// delete-bucket) against an already-open read-write handle.
+func Execute(db *bbolt.DB, operation, bucketPath, keyBase64, valueBase64 string) error {
+	switch operation {
+	case "create-bucket":
+		return createBucket(db, bucketPath)
+	case "put":
+		return putKeyValue(db, bucketPath, keyBase64, valueBase64)
+	case "delete-key":
+		return deleteKey(db, bucketPath, keyBase64)
+	case "delete-bucket":
+		return deleteBucket(db, bucketPath)
+	default:
+		return fmt.Errorf("unknown operation: %s", operation)
+	}
+}
+
 func Run() {
-	var dbPath, operation, bucketPath, keyBase64, valueBase64 string
+	var dbPath, operation, bucketPath, keyBase64, keyHex, keyUint64, valueBase64 string
 	flag.StringVar(&dbPath, "db", "", "DB path")
 	flag.StringVar(&operation, "op", "", "Operation: create-bucket, put, delete-key, delete-bucket")
 	flag.StringVar(&bucketPath, "path", "", "bucket path (slash-separated)")
 	flag.StringVar(&keyBase64, "key", "", "key (base64)")
+	flag.StringVar(&keyHex, "key-hex", "", "key (hex), alternative to -key")
+	flag.StringVar(&keyUint64, "key-uint64", "", "key as a big-endian uint64, alternative to -key")
 	flag.StringVar(&valueBase64, "value", "", "value (base64)")
 	flag.Parse()
 
@@ -25,6 +44,16 @@ func Run() {
 	if operation == "" {
 		common.Fail("write", fmt.Errorf("missing -op"))
 	}
+	if keyHex != "" || keyUint64 != "" {
+		if keyBase64 != "" {
+			common.Fail("write", fmt.Errorf("only one of -key, -key-hex, -key-uint64 may be set"))
+		}
+		key, err := resolveTypedKey(keyHex, keyUint64)
+		if err != nil {
+			common.Fail("write", err)
+		}
+		keyBase64 = base64.StdEncoding.EncodeToString(key)
+	}
 
 	// Open DB in read-write mode
 	db, err := bbolt.Open(dbPath, 0644, nil)
@@ -33,26 +62,24 @@ func Run() {
 	}
 	defer db.Close()
 
-	switch operation {
-	case "create-bucket":
-		err = createBucket(db, bucketPath)
-	case "put":
-		err = putKeyValue(db, bucketPath, keyBase64, valueBase64)
-	case "delete-key":
-		err = deleteKey(db, bucketPath, keyBase64)
-	case "delete-bucket":
-		err = deleteBucket(db, bucketPath)
-	default:
-		common.Fail("write", fmt.Errorf("unknown operation: %s", operation))
-	}
-
-	if err != nil {
+	if err := Execute(db, operation, bucketPath, keyBase64, valueBase64); err != nil {
 		common.Fail("write", err)
 	}
 
 	common.PrintJSON(common.Result{"ok": true})
 }
 
+// resolveTypedKey encodes exactly one of -key-hex/-key-uint64 to raw bytes.
+func resolveTypedKey(keyHex, keyUint64 string) ([]byte, error) {
+	if keyHex != "" && keyUint64 != "" {
+		return nil, fmt.Errorf("only one of -key, -key-hex, -key-uint64 may be set")
+	}
+	if keyHex != "" {
+		return common.EncodeKey("hex", keyHex)
+	}
+	return common.EncodeKey("uint64be", keyUint64)
+}
+
 func createBucket(db *bbolt.DB, bucketPath string) error {
 	if bucketPath == "" {
 		return fmt.Errorf("bucket path required")